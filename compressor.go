@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"sync"
+
+	"go.unistack.org/micro/v3/codec"
+)
+
+// Compressor compresses and decompresses message bodies for the wire, keyed
+// by the name carried in the grpc-encoding / grpc-accept-encoding headers.
+type Compressor interface {
+	Name() string
+	Compress([]byte) ([]byte, error)
+	Decompress([]byte) ([]byte, error)
+}
+
+var (
+	mu          sync.RWMutex
+	compressors = make(map[string]Compressor)
+)
+
+// RegisterCompressor registers a Compressor under its Name(), overwriting
+// any previously registered compressor with the same name. Additional
+// encodings (e.g. snappy, br) can be wired in via init().
+func RegisterCompressor(c Compressor) {
+	mu.Lock()
+	compressors[c.Name()] = c
+	mu.Unlock()
+}
+
+func getCompressor(name string) (Compressor, bool) {
+	mu.RLock()
+	c, ok := compressors[name]
+	mu.RUnlock()
+	return c, ok
+}
+
+func init() {
+	RegisterCompressor(identityCompressor{})
+	RegisterCompressor(gzipCompressor{})
+	RegisterCompressor(deflateCompressor{})
+}
+
+type identityCompressor struct{}
+
+func (identityCompressor) Name() string                        { return "identity" }
+func (identityCompressor) Compress(b []byte) ([]byte, error)   { return b, nil }
+func (identityCompressor) Decompress(b []byte) ([]byte, error) { return b, nil }
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string { return "gzip" }
+
+func (gzipCompressor) Compress(b []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+type deflateCompressor struct{}
+
+func (deflateCompressor) Name() string { return "deflate" }
+
+func (deflateCompressor) Compress(b []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w, err := flate.NewWriter(buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (deflateCompressor) Decompress(b []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(b))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+type compressorKey struct{}
+
+// WithCompressor selects the named compressor (as registered via
+// RegisterCompressor) used by Write when marshalling outgoing messages.
+func WithCompressor(name string) codec.Option {
+	return func(o *codec.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, compressorKey{}, name)
+	}
+}
+
+// compressorName resolves which compressor to use for an outgoing message:
+// an explicit grpc-encoding header takes precedence over the codec default
+// set via WithCompressor.
+func (c *grpcCodec) compressorName(m *codec.Message) string {
+	if enc := m.Header["grpc-encoding"]; len(enc) > 0 {
+		return enc
+	}
+	return c.defaultCompressorName()
+}
+
+// defaultCompressorName returns the compressor set via WithCompressor on
+// this codec, for callers (e.g. Stream) that have no per-message header to
+// consult.
+func (c *grpcCodec) defaultCompressorName() string {
+	if c.opts.Context != nil {
+		if name, ok := c.opts.Context.Value(compressorKey{}).(string); ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package grpc
+
+import "testing"
+
+func TestCompressorsRoundTrip(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+
+	for _, name := range []string{"identity", "gzip", "deflate"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			c, ok := getCompressor(name)
+			if !ok {
+				t.Fatalf("compressor %q not registered", name)
+			}
+
+			compressed, err := c.Compress(data)
+			if err != nil {
+				t.Fatalf("Compress: %v", err)
+			}
+
+			decompressed, err := c.Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress: %v", err)
+			}
+
+			if string(decompressed) != string(data) {
+				t.Fatalf("round trip mismatch: got %q want %q", decompressed, data)
+			}
+		})
+	}
+}
+
+func TestGetCompressorUnknown(t *testing.T) {
+	if _, ok := getCompressor("does-not-exist"); ok {
+		t.Fatal("expected no compressor registered under this name")
+	}
+}
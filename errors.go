@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+
+	"go.unistack.org/micro/v3/codec"
+	merrors "go.unistack.org/micro/v3/errors"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type errorDetailsKey struct{}
+
+// WithErrorDetails attaches proto detail messages that Write marshals into
+// the grpc-status-details-bin trailer alongside grpc-status/grpc-message
+// whenever this codec writes a codec.Error message other than the "EOS"
+// end-of-stream marker.
+func WithErrorDetails(details ...proto.Message) codec.Option {
+	return func(o *codec.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, errorDetailsKey{}, details)
+	}
+}
+
+// errorDetails returns the proto detail messages attached via
+// WithErrorDetails, if any.
+func (c *grpcCodec) errorDetails() []proto.Message {
+	if c.opts.Context == nil {
+		return nil
+	}
+	details, _ := c.opts.Context.Value(errorDetailsKey{}).([]proto.Message)
+	return details
+}
+
+// grpcCodeFromHTTP maps the HTTP-ish status codes carried by a micro
+// *errors.Error to the standard gRPC status codes used in grpc-status.
+var grpcCodeFromHTTP = map[int32]int{
+	400: 3,  // InvalidArgument
+	401: 16, // Unauthenticated
+	403: 7,  // PermissionDenied
+	404: 5,  // NotFound
+	408: 4,  // DeadlineExceeded
+	409: 10, // Aborted
+	412: 9,  // FailedPrecondition
+	429: 8,  // ResourceExhausted
+	499: 1,  // Canceled
+	500: 13, // Internal
+	501: 12, // Unimplemented
+	503: 14, // Unavailable
+}
+
+// statusCodeMessage resolves the grpc-status code and grpc-message that
+// should be sent for a *codec.Message's Error field: if it parses as a
+// micro *errors.Error, its Code is mapped to the matching gRPC status code;
+// otherwise it falls back to Internal (13) with the raw error string.
+func statusCodeMessage(errStr string) (int, string) {
+	merr := merrors.Parse(errStr)
+	if merr != nil && merr.Code != 0 {
+		code := 13
+		if gc, ok := grpcCodeFromHTTP[merr.Code]; ok {
+			code = gc
+		}
+		return code, merr.Detail
+	}
+
+	return 13, errStr
+}
+
+// encodeStatusDetails marshals code/message/details into a google.rpc.Status
+// proto and returns it base64-encoded, ready for grpc-status-details-bin.
+func encodeStatusDetails(code int, message string, details ...proto.Message) (string, error) {
+	s := &status.Status{Code: int32(code), Message: message}
+
+	for _, d := range details {
+		a, err := anypb.New(d)
+		if err != nil {
+			return "", err
+		}
+		s.Details = append(s.Details, a)
+	}
+
+	buf, err := proto.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// decodeStatusDetails reverses encodeStatusDetails, returning the detail
+// messages carried in a grpc-status-details-bin trailer value as opaque
+// *anypb.Any messages.
+func decodeStatusDetails(bin string) ([]proto.Message, error) {
+	buf, err := base64.StdEncoding.DecodeString(bin)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &status.Status{}
+	if err := proto.Unmarshal(buf, s); err != nil {
+		return nil, err
+	}
+
+	details := make([]proto.Message, 0, len(s.Details))
+	for _, a := range s.Details {
+		details = append(details, a)
+	}
+
+	return details, nil
+}
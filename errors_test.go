@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"testing"
+
+	"go.unistack.org/micro/v3/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestStatusDetailsRoundTrip(t *testing.T) {
+	bin, err := encodeStatusDetails(5, "not found", wrapperspb.String("extra context"))
+	if err != nil {
+		t.Fatalf("encodeStatusDetails: %v", err)
+	}
+
+	details, err := decodeStatusDetails(bin)
+	if err != nil {
+		t.Fatalf("decodeStatusDetails: %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("got %d details, want 1", len(details))
+	}
+}
+
+func TestStatusCodeMessageFallback(t *testing.T) {
+	code, msg := statusCodeMessage("boom")
+	if code != 13 || msg != "boom" {
+		t.Fatalf("got (%d, %q), want (13, %q)", code, msg, "boom")
+	}
+}
+
+// TestNewStatusErrorParsesDetailsBin guards the unary Status() path: the
+// grpc-status-details-bin trailer set by Write's WithErrorDetails wiring
+// must come back out as StatusError.Details on the reading side.
+func TestNewStatusErrorParsesDetailsBin(t *testing.T) {
+	bin, err := encodeStatusDetails(5, "not found", wrapperspb.String("x"))
+	if err != nil {
+		t.Fatalf("encodeStatusDetails: %v", err)
+	}
+
+	se := newStatusError(metadata.Metadata{
+		"grpc-status":             "5",
+		"grpc-message":            "not found",
+		"grpc-status-details-bin": bin,
+	}).(*StatusError)
+
+	if se.Code != 5 || se.Message != "not found" || len(se.Details) != 1 {
+		t.Fatalf("got %+v", se)
+	}
+}
@@ -2,10 +2,12 @@
 package grpc
 
 import (
-	"encoding/json"
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"go.unistack.org/micro/v3/codec"
@@ -29,6 +31,36 @@ var (
 	}
 )
 
+type marshalOptionsKey struct{}
+
+// WithMarshalOptions sets jsonpb.MarshalOptions used to marshal
+// proto.Message values for the application/grpc+json content type. Without
+// it, application/grpc+json uses plain encoding/json; DefaultMarshalOptions
+// is not applied.
+func WithMarshalOptions(o jsonpb.MarshalOptions) codec.Option {
+	return func(opts *codec.Options) {
+		if opts.Context == nil {
+			opts.Context = context.Background()
+		}
+		opts.Context = context.WithValue(opts.Context, marshalOptionsKey{}, o)
+	}
+}
+
+type unmarshalOptionsKey struct{}
+
+// WithUnmarshalOptions sets jsonpb.UnmarshalOptions used to unmarshal
+// proto.Message values for the application/grpc+json content type. Without
+// it, application/grpc+json uses plain encoding/json; DefaultUnmarshalOptions
+// is not applied.
+func WithUnmarshalOptions(o jsonpb.UnmarshalOptions) codec.Option {
+	return func(opts *codec.Options) {
+		if opts.Context == nil {
+			opts.Context = context.Background()
+		}
+		opts.Context = context.WithValue(opts.Context, unmarshalOptionsKey{}, o)
+	}
+}
+
 type jsonpbCodec struct {
 	opts codec.Options
 }
@@ -39,7 +71,15 @@ const (
 
 type grpcCodec struct {
 	opts        codec.Options
+	subCodecs   map[string]SubCodec
 	ContentType string
+	Encoding    string
+	GRPCWeb     bool
+	// Status holds the error parsed from the grpc-status/grpc-message/
+	// grpc-status-details-bin headers by the last ReadHeader call, or nil
+	// when the last response completed successfully. Reached by external
+	// callers via the package-level Status function.
+	Status *StatusError
 }
 
 func (c *grpcCodec) ReadHeader(conn io.Reader, m *codec.Message, t codec.MessageType) error {
@@ -47,6 +87,19 @@ func (c *grpcCodec) ReadHeader(conn io.Reader, m *codec.Message, t codec.Message
 		c.ContentType = ct
 	}
 
+	if enc := m.Header["grpc-encoding"]; len(enc) > 0 {
+		c.Encoding = enc
+	}
+
+	c.Status = nil
+	if status := m.Header["grpc-status"]; len(status) > 0 && status != "0" {
+		c.Status = newStatusError(metadata.Metadata{
+			"grpc-status":             status,
+			"grpc-message":            m.Header["grpc-message"],
+			"grpc-status-details-bin": m.Header["grpc-status-details-bin"],
+		}).(*StatusError)
+	}
+
 	// service method
 	path := m.Header[":path"]
 	if len(path) == 0 || path[0] != '/' {
@@ -85,29 +138,20 @@ func (c *grpcCodec) Unmarshal(d []byte, v interface{}, opts ...codec.Option) err
 		return nil
 	}
 
-	if c.ContentType == "application/grpc+json" {
-		return json.Unmarshal(d, v)
-	}
-
-	if _, ok := v.(proto.Message); !ok {
-		return codec.ErrInvalidMessage
-	}
-
-	unmarshalOptions := DefaultUnmarshalOptions
-	if options.Context != nil {
-		if f, ok := options.Context.Value(unmarshalOptionsKey{}).(jsonpb.UnmarshalOptions); ok {
-			unmarshalOptions = f
+	if c.ContentType == "application/grpc+json" && options.Context != nil {
+		if m, ok := v.(proto.Message); ok {
+			if f, ok := options.Context.Value(unmarshalOptionsKey{}).(jsonpb.UnmarshalOptions); ok {
+				return f.Unmarshal(d, m)
+			}
 		}
 	}
 
-	switch c.ContentType {
-	case "application/grpc+json":
-		return unmarshalOptions.Unmarshal(d, v.(proto.Message))
-	case "application/grpc+proto", "application/grpc":
-		return proto.Unmarshal(d, v.(proto.Message))
+	sc, ok := c.subCodec(c.ContentType)
+	if !ok {
+		return codec.ErrUnknownContentType
 	}
 
-	return codec.ErrInvalidMessage
+	return sc.Unmarshal(d, v)
 }
 
 func (c *grpcCodec) Marshal(v interface{}, opts ...codec.Option) ([]byte, error) {
@@ -128,29 +172,20 @@ func (c *grpcCodec) Marshal(v interface{}, opts ...codec.Option) ([]byte, error)
 		return m.Data, nil
 	}
 
-	if c.ContentType == "application/grpc+json" {
-		return json.Marshal(v)
-	}
-
-	if _, ok := v.(proto.Message); !ok {
-		return nil, codec.ErrInvalidMessage
-	}
-
-	marshalOptions := DefaultMarshalOptions
-	if options.Context != nil {
-		if f, ok := options.Context.Value(marshalOptionsKey{}).(jsonpb.MarshalOptions); ok {
-			marshalOptions = f
+	if c.ContentType == "application/grpc+json" && options.Context != nil {
+		if m, ok := v.(proto.Message); ok {
+			if f, ok := options.Context.Value(marshalOptionsKey{}).(jsonpb.MarshalOptions); ok {
+				return f.Marshal(m)
+			}
 		}
 	}
 
-	switch c.ContentType {
-	case "application/grpc+json":
-		return marshalOptions.Marshal(v.(proto.Message))
-	case "application/grpc+proto", "application/grpc":
-		return proto.Marshal(v.(proto.Message))
+	sc, ok := c.subCodec(c.ContentType)
+	if !ok {
+		return nil, codec.ErrUnknownContentType
 	}
 
-	return nil, codec.ErrUnknownContentType
+	return sc.Marshal(v)
 }
 
 func (c *grpcCodec) ReadBody(conn io.Reader, v interface{}) error {
@@ -159,13 +194,37 @@ func (c *grpcCodec) ReadBody(conn io.Reader, v interface{}) error {
 		return nil
 	}
 
-	n, buf, err := c.decode(conn)
+	cf, buf, err := c.decode(conn)
 	if err != nil {
 		return err
-	} else if n == 0 {
+	} else if buf == nil {
 		return nil
 	}
 
+	if c.isGRPCWeb() && c.isGRPCWebText() {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(buf)))
+		n, derr := base64.StdEncoding.Decode(decoded, buf)
+		if derr != nil {
+			return derr
+		}
+		buf = decoded[:n]
+	}
+
+	if cf == 1 {
+		cmp, ok := getCompressor(c.Encoding)
+		if !ok {
+			return fmt.Errorf("grpc: unsupported compressor %q", c.Encoding)
+		}
+
+		if buf, err = cmp.Decompress(buf); err != nil {
+			return err
+		}
+
+		if len(buf) > codec.DefaultMaxMsgSize {
+			return fmt.Errorf("grpc: received message larger than max (%d vs. %d)", len(buf), codec.DefaultMaxMsgSize)
+		}
+	}
+
 	return c.Unmarshal(buf, v)
 }
 
@@ -185,21 +244,33 @@ func (c *grpcCodec) Write(conn io.Writer, m *codec.Message, v interface{}) error
 		m.Header[":authority"] = m.Target
 		m.Header["content-type"] = c.ContentType
 	case codec.Response:
-		m.Header["Trailer"] = "grpc-status" //, grpc-message"
 		m.Header["content-type"] = c.ContentType
 		m.Header[":status"] = "200"
+		if c.isGRPCWeb() {
+			break
+		}
+		m.Header["Trailer"] = "grpc-status" //, grpc-message"
 		m.Header["grpc-status"] = "0"
 		//		m.Header["grpc-message"] = ""
 	case codec.Error:
-		m.Header["Trailer"] = "grpc-status, grpc-message"
 		// micro end of stream
 		if m.Error == "EOS" {
 			m.Header["grpc-status"] = "0"
 		} else {
-			m.Header["grpc-message"] = m.Error
-			m.Header["grpc-status"] = "13"
+			code, msg := statusCodeMessage(m.Error)
+			m.Header["grpc-message"] = msg
+			m.Header["grpc-status"] = strconv.Itoa(code)
+
+			if bin, err := encodeStatusDetails(code, msg, c.errorDetails()...); err == nil {
+				m.Header["grpc-status-details-bin"] = bin
+			}
 		}
 
+		if c.isGRPCWeb() {
+			return c.writeWebTrailer(conn, m)
+		}
+
+		m.Header["Trailer"] = "grpc-status, grpc-message"
 		return nil
 	}
 
@@ -214,8 +285,34 @@ func (c *grpcCodec) Write(conn io.Writer, m *codec.Message, v interface{}) error
 		return nil
 	}
 
+	var cf uint8
+	if name := c.compressorName(m); name != "" && name != "identity" {
+		cmp, ok := getCompressor(name)
+		if !ok {
+			m.Header["grpc-status"] = "12"
+			m.Header["grpc-message"] = fmt.Sprintf("grpc: unsupported compressor %q", name)
+			return errors.New(m.Header["grpc-message"])
+		}
+
+		if buf, err = cmp.Compress(buf); err != nil {
+			m.Header["grpc-status"] = "13"
+			m.Header["grpc-message"] = err.Error()
+			return err
+		}
+
+		cf = 1
+		m.Header["grpc-encoding"] = name
+	}
+
 	m.Body = buf
-	return c.encode(0, buf, conn)
+
+	if c.isGRPCWeb() && c.isGRPCWebText() {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(buf)))
+		base64.StdEncoding.Encode(encoded, buf)
+		buf = encoded
+	}
+
+	return c.encode(cf, buf, conn)
 }
 
 func (c *grpcCodec) String() string {
@@ -223,5 +320,29 @@ func (c *grpcCodec) String() string {
 }
 
 func NewCodec(opts ...codec.Option) codec.Codec {
-	return &grpcCodec{opts: codec.NewOptions(opts...), ContentType: "application/grpc"}
+	options := codec.NewOptions(opts...)
+
+	c := &grpcCodec{opts: options, ContentType: "application/grpc"}
+	if options.Context != nil {
+		if m, ok := options.Context.Value(subCodecsKey{}).(map[string]SubCodec); ok {
+			c.subCodecs = m
+		}
+		if web, ok := options.Context.Value(grpcWebKey{}).(bool); ok {
+			c.GRPCWeb = web
+		}
+	}
+
+	return c
+}
+
+// Status returns the error parsed from c's last ReadHeader call off the
+// grpc-status/grpc-message/grpc-status-details-bin headers, or nil if that
+// call completed successfully (or c was not returned by NewCodec). Use this
+// to reach the unary equivalent of the error Stream.Recv returns.
+func Status(c codec.Codec) *StatusError {
+	gc, ok := c.(*grpcCodec)
+	if !ok {
+		return nil
+	}
+	return gc.Status
 }
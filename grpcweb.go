@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.unistack.org/micro/v3/codec"
+	"go.unistack.org/micro/v3/metadata"
+)
+
+// grpcWebTrailerFlag marks a frame as carrying trailers rather than a
+// message, per the gRPC-Web wire format (the MSB of the flag byte).
+const grpcWebTrailerFlag = 0x80
+
+type grpcWebKey struct{}
+
+// WithGRPCWeb switches the codec between standard gRPC framing and
+// gRPC-Web framing: trailers travel as a second length-prefixed frame (with
+// the 0x80 bit of the flag byte set) carrying HTTP/1.1-style
+// "grpc-status: N\r\ngrpc-message: ...\r\n" bytes, instead of being emitted
+// as HTTP/2 trailers on m.Header.
+func WithGRPCWeb(enabled bool) codec.Option {
+	return func(o *codec.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, grpcWebKey{}, enabled)
+	}
+}
+
+func init() {
+	RegisterSubCodec("application/grpc-web", protoSubCodec{})
+	RegisterSubCodec("application/grpc-web+proto", protoSubCodec{})
+	RegisterSubCodec("application/grpc-web-text", protoSubCodec{})
+}
+
+// isGRPCWeb reports whether c should use gRPC-Web framing, either because it
+// was built with WithGRPCWeb(true) or because the negotiated content-type is
+// one of the grpc-web variants.
+func (c *grpcCodec) isGRPCWeb() bool {
+	return c.GRPCWeb || strings.HasPrefix(c.ContentType, "application/grpc-web")
+}
+
+// isGRPCWebText reports whether payloads should be base64-encoded/decoded
+// on the wire, as required by the "application/grpc-web-text" variant.
+func (c *grpcCodec) isGRPCWebText() bool {
+	return strings.HasSuffix(c.ContentType, "-text")
+}
+
+// writeWebTrailer serialises m.Header's grpc-status, grpc-message and
+// grpc-status-details-bin as an HTTP/1.1-style trailer block and writes it
+// as a gRPC-Web trailer frame. For the "-text" content-type variant the
+// trailer block is base64-encoded on the wire, same as message frames.
+func (c *grpcCodec) writeWebTrailer(conn io.Writer, m *codec.Message) error {
+	buf := new(bytes.Buffer)
+	for _, k := range [...]string{"grpc-status", "grpc-message", "grpc-status-details-bin"} {
+		if v, ok := m.Header[k]; ok {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+
+	trailer := buf.Bytes()
+	if c.isGRPCWebText() {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(trailer)))
+		base64.StdEncoding.Encode(encoded, trailer)
+		trailer = encoded
+	}
+
+	return c.encode(grpcWebTrailerFlag, trailer, conn)
+}
+
+// parseWebTrailer turns an HTTP/1.1-style trailer block, as written by
+// writeWebTrailer, back into metadata.
+func parseWebTrailer(buf []byte) metadata.Metadata {
+	md := make(metadata.Metadata)
+	for _, line := range strings.Split(string(buf), "\r\n") {
+		if line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		md[k] = v
+	}
+	return md
+}
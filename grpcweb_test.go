@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"go.unistack.org/micro/v3/codec"
+)
+
+// TestWebTrailerRoundTrip guards against writeWebTrailer/parseWebTrailer
+// disagreeing about base64 framing for the "-text" content-type variant.
+func TestWebTrailerRoundTrip(t *testing.T) {
+	for _, ct := range []string{"application/grpc-web+proto", "application/grpc-web-text"} {
+		ct := ct
+		t.Run(ct, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := &grpcCodec{ContentType: ct}
+
+			m := &codec.Message{Header: map[string]string{
+				"grpc-status":  "5",
+				"grpc-message": "not found",
+			}}
+
+			if err := c.writeWebTrailer(buf, m); err != nil {
+				t.Fatalf("writeWebTrailer: %v", err)
+			}
+
+			cf, frame, err := c.decode(buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if cf&grpcWebTrailerFlag == 0 {
+				t.Fatalf("cf = %#x, want trailer flag set", cf)
+			}
+
+			if c.isGRPCWebText() {
+				decoded := make([]byte, base64.StdEncoding.DecodedLen(len(frame)))
+				n, err := base64.StdEncoding.Decode(decoded, frame)
+				if err != nil {
+					t.Fatalf("trailer frame is not valid base64: %v", err)
+				}
+				frame = decoded[:n]
+			}
+
+			md := parseWebTrailer(frame)
+			if md["grpc-status"] != "5" || md["grpc-message"] != "not found" {
+				t.Fatalf("got %+v", md)
+			}
+		})
+	}
+}
+
+func TestIsGRPCWeb(t *testing.T) {
+	cases := []struct {
+		c       *grpcCodec
+		web     bool
+		webText bool
+	}{
+		{&grpcCodec{ContentType: "application/grpc+proto"}, false, false},
+		{&grpcCodec{ContentType: "application/grpc-web+proto"}, true, false},
+		{&grpcCodec{ContentType: "application/grpc-web-text"}, true, true},
+		{&grpcCodec{ContentType: "application/grpc+proto", GRPCWeb: true}, true, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.c.isGRPCWeb(); got != tc.web {
+			t.Errorf("isGRPCWeb(%q) = %v, want %v", tc.c.ContentType, got, tc.web)
+		}
+		if got := tc.c.isGRPCWebText(); got != tc.webText {
+			t.Errorf("isGRPCWebText(%q) = %v, want %v", tc.c.ContentType, got, tc.webText)
+		}
+	}
+}
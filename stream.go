@@ -0,0 +1,182 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+
+	"go.unistack.org/micro/v3/codec"
+	"go.unistack.org/micro/v3/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Stream frames multiple messages over a single connection, for
+// client- and bidi-streaming RPCs where codec.Codec's ReadBody/Write only
+// handle one message per call. go.unistack.org/micro/v3/codec has no
+// equivalent type, so callers that need streaming use this one directly.
+type Stream interface {
+	Send(interface{}) error
+	Recv(interface{}) error
+	CloseSend() error
+	Trailer() metadata.Metadata
+}
+
+var _ Stream = (*stream)(nil)
+
+// trailerer is implemented by connections that can surface gRPC trailers
+// (grpc-status, grpc-message, grpc-status-details-bin) once the final frame
+// of a stream has been read.
+type trailerer interface {
+	Trailer() metadata.Metadata
+}
+
+// StatusError is returned from Stream.Recv (and, for unary reads, via the
+// package-level Status function) when a call terminates with a non-zero
+// grpc-status trailer. Details holds the google.rpc.Status detail messages
+// carried in grpc-status-details-bin, if any.
+type StatusError struct {
+	Code    int
+	Message string
+	Details []proto.Message
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("grpc: code = %d desc = %q", e.Code, e.Message)
+}
+
+func newStatusError(md metadata.Metadata) error {
+	code, _ := strconv.Atoi(md["grpc-status"])
+	se := &StatusError{Code: code, Message: md["grpc-message"]}
+
+	if bin := md["grpc-status-details-bin"]; bin != "" {
+		if details, err := decodeStatusDetails(bin); err == nil {
+			se.Details = details
+		}
+	}
+
+	return se
+}
+
+// stream is the Stream implementation used by NewStream.
+type stream struct {
+	conn    io.ReadWriter
+	codec   *grpcCodec
+	trailer metadata.Metadata
+	closed  bool
+}
+
+// NewStream wraps conn in a gRPC length-prefixed frame reader/writer for the
+// given content type (e.g. "application/grpc+proto"), allowing repeated
+// Send/Recv calls over the same connection.
+func NewStream(conn io.ReadWriter, ct string) Stream {
+	return &stream{
+		conn:  conn,
+		codec: &grpcCodec{opts: codec.NewOptions(), ContentType: ct},
+	}
+}
+
+func (s *stream) Send(v interface{}) error {
+	if s.closed {
+		return io.ErrClosedPipe
+	}
+
+	buf, err := s.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var cf uint8
+	if name := s.codec.defaultCompressorName(); name != "" && name != "identity" {
+		cmp, ok := getCompressor(name)
+		if !ok {
+			return fmt.Errorf("grpc: unsupported compressor %q", name)
+		}
+
+		if buf, err = cmp.Compress(buf); err != nil {
+			return err
+		}
+
+		cf = 1
+	}
+
+	if s.codec.isGRPCWeb() && s.codec.isGRPCWebText() {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(buf)))
+		base64.StdEncoding.Encode(encoded, buf)
+		buf = encoded
+	}
+
+	return s.codec.encode(cf, buf, s.conn)
+}
+
+func (s *stream) Recv(v interface{}) error {
+	cf, buf, err := s.codec.decode(s.conn)
+	if err == io.EOF {
+		if t, ok := s.conn.(trailerer); ok {
+			s.trailer = t.Trailer()
+		}
+		if status := s.trailer["grpc-status"]; status == "" || status == "0" {
+			return io.EOF
+		}
+		return newStatusError(s.trailer)
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.codec.isGRPCWeb() && s.codec.isGRPCWebText() {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(buf)))
+		n, derr := base64.StdEncoding.Decode(decoded, buf)
+		if derr != nil {
+			return derr
+		}
+		buf = decoded[:n]
+	}
+
+	if cf&grpcWebTrailerFlag != 0 {
+		s.trailer = parseWebTrailer(buf)
+		if status := s.trailer["grpc-status"]; status == "" || status == "0" {
+			return io.EOF
+		}
+		return newStatusError(s.trailer)
+	}
+
+	if cf&1 != 0 {
+		name := s.codec.Encoding
+		if name == "" {
+			name = s.codec.defaultCompressorName()
+		}
+
+		cmp, ok := getCompressor(name)
+		if !ok {
+			return fmt.Errorf("grpc: unsupported compressor %q", name)
+		}
+
+		if buf, err = cmp.Decompress(buf); err != nil {
+			return err
+		}
+
+		if len(buf) > codec.DefaultMaxMsgSize {
+			return fmt.Errorf("grpc: received message larger than max (%d vs. %d)", len(buf), codec.DefaultMaxMsgSize)
+		}
+	}
+
+	return s.codec.Unmarshal(buf, v)
+}
+
+func (s *stream) CloseSend() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if cw, ok := s.conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+
+	return nil
+}
+
+func (s *stream) Trailer() metadata.Metadata {
+	return s.trailer
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"net"
+	"testing"
+
+	"go.unistack.org/micro/v3/codec"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestStreamSendRecv(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	client := NewStream(clientConn, "application/grpc+proto")
+	server := NewStream(serverConn, "application/grpc+proto")
+
+	want := wrapperspb.String("hello stream")
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- client.Send(want) }()
+
+	got := &wrapperspb.StringValue{}
+	if err := server.Recv(got); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got.GetValue() != want.GetValue() {
+		t.Fatalf("got %q, want %q", got.GetValue(), want.GetValue())
+	}
+}
+
+// TestStreamSendRecvCompressed guards against a regression where Send/Recv
+// ignored the negotiated compressor entirely, leaving unary the only path
+// with working compression.
+func TestStreamSendRecvCompressed(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	opts := codec.NewOptions(WithCompressor("gzip"))
+	client := &stream{conn: clientConn, codec: &grpcCodec{opts: opts, ContentType: "application/grpc+proto"}}
+	server := &stream{conn: serverConn, codec: &grpcCodec{opts: opts, ContentType: "application/grpc+proto"}}
+
+	want := wrapperspb.String("compressed hello")
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- client.Send(want) }()
+
+	got := &wrapperspb.StringValue{}
+	if err := server.Recv(got); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got.GetValue() != want.GetValue() {
+		t.Fatalf("got %q, want %q", got.GetValue(), want.GetValue())
+	}
+}
+
+func TestStreamRecvEOF(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	server := NewStream(serverConn, "application/grpc+proto")
+
+	clientConn.Close()
+
+	got := &wrapperspb.StringValue{}
+	if err := server.Recv(got); err == nil {
+		t.Fatal("expected an error once the peer closes the connection")
+	}
+}
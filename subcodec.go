@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.unistack.org/micro/v3/codec"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// SubCodec marshals/unmarshals a message body for a single content-type,
+// independent of the gRPC length-prefixed frame it travels in.
+type SubCodec interface {
+	Marshal(interface{}) ([]byte, error)
+	Unmarshal([]byte, interface{}) error
+}
+
+var (
+	subCodecMu sync.RWMutex
+	subCodecs  = make(map[string]SubCodec)
+)
+
+// RegisterSubCodec registers a SubCodec for contentType, overwriting any
+// previously registered entry for it. Call from init() to wire in additional
+// encodings (e.g. Avro, CBOR).
+func RegisterSubCodec(contentType string, c SubCodec) {
+	subCodecMu.Lock()
+	subCodecs[contentType] = c
+	subCodecMu.Unlock()
+}
+
+func getSubCodec(contentType string) (SubCodec, bool) {
+	subCodecMu.RLock()
+	c, ok := subCodecs[contentType]
+	subCodecMu.RUnlock()
+	return c, ok
+}
+
+func init() {
+	RegisterSubCodec("application/grpc", protoSubCodec{})
+	RegisterSubCodec("application/grpc+proto", protoSubCodec{})
+	RegisterSubCodec("application/grpc+json", jsonSubCodec{})
+	RegisterSubCodec("application/grpc+bytes", bytesSubCodec{})
+	RegisterSubCodec("application/grpc+msgpack", msgpackSubCodec{})
+	RegisterSubCodec("application/grpc+yaml", yamlSubCodec{})
+}
+
+type subCodecsKey struct{}
+
+// WithSubCodecs sets the codec's own content-type -> SubCodec table. Its
+// entries take precedence over the process-wide registry; content types not
+// in the table still fall back to the registry.
+func WithSubCodecs(m map[string]SubCodec) codec.Option {
+	return func(o *codec.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, subCodecsKey{}, m)
+	}
+}
+
+// subCodec resolves the SubCodec for contentType, preferring the codec
+// instance's own table (set via WithSubCodecs) over the process-wide one.
+func (c *grpcCodec) subCodec(contentType string) (SubCodec, bool) {
+	if c.subCodecs != nil {
+		if sc, ok := c.subCodecs[contentType]; ok {
+			return sc, true
+		}
+	}
+	return getSubCodec(contentType)
+}
+
+type protoSubCodec struct{}
+
+func (protoSubCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, codec.ErrInvalidMessage
+	}
+	return proto.Marshal(m)
+}
+
+func (protoSubCodec) Unmarshal(d []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return codec.ErrInvalidMessage
+	}
+	return proto.Unmarshal(d, m)
+}
+
+// jsonSubCodec always uses plain encoding/json, matching the behavior
+// application/grpc+json had before the SubCodec registry existed: it does
+// not switch to protojson for proto.Message values, to avoid silently
+// changing the wire format (enum representation, field casing, wrapper
+// types) for existing callers of this content-type.
+type jsonSubCodec struct{}
+
+func (jsonSubCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonSubCodec) Unmarshal(d []byte, v interface{}) error {
+	return json.Unmarshal(d, v)
+}
+
+type bytesSubCodec struct{}
+
+func (bytesSubCodec) Marshal(v interface{}) ([]byte, error) {
+	switch vv := v.(type) {
+	case *[]byte:
+		return *vv, nil
+	case []byte:
+		return vv, nil
+	}
+	return nil, codec.ErrInvalidMessage
+}
+
+func (bytesSubCodec) Unmarshal(d []byte, v interface{}) error {
+	vv, ok := v.(*[]byte)
+	if !ok {
+		return codec.ErrInvalidMessage
+	}
+	*vv = d
+	return nil
+}
+
+type msgpackSubCodec struct{}
+
+func (msgpackSubCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackSubCodec) Unmarshal(d []byte, v interface{}) error {
+	return msgpack.Unmarshal(d, v)
+}
+
+type yamlSubCodec struct{}
+
+func (yamlSubCodec) Marshal(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlSubCodec) Unmarshal(d []byte, v interface{}) error {
+	return yaml.Unmarshal(d, v)
+}
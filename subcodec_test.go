@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSubCodecRegistryDefaults(t *testing.T) {
+	for _, ct := range []string{
+		"application/grpc",
+		"application/grpc+proto",
+		"application/grpc+json",
+		"application/grpc+bytes",
+		"application/grpc+msgpack",
+		"application/grpc+yaml",
+		"application/grpc-web",
+		"application/grpc-web+proto",
+		"application/grpc-web-text",
+	} {
+		if _, ok := getSubCodec(ct); !ok {
+			t.Errorf("no SubCodec registered for %q", ct)
+		}
+	}
+}
+
+func TestBytesSubCodecRoundTrip(t *testing.T) {
+	sc := bytesSubCodec{}
+	want := []byte("raw frame")
+
+	buf, err := sc.Marshal(&want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got []byte
+	if err := sc.Unmarshal(buf, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if string(got) != string(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// TestJSONSubCodecPlainJSON pins application/grpc+json to plain
+// encoding/json rather than protojson, so a later refactor can't silently
+// reintroduce the enum/field-casing behavior change this was reverted for.
+func TestJSONSubCodecPlainJSON(t *testing.T) {
+	sc := jsonSubCodec{}
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	buf, err := sc.Marshal(&payload{Name: "foo"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if string(buf) != `{"name":"foo"}` {
+		t.Fatalf("got %s, want plain encoding/json output", buf)
+	}
+
+	var got payload
+	if err := sc.Unmarshal(buf, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if got.Name != "foo" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestProtoSubCodecRoundTrip(t *testing.T) {
+	sc := protoSubCodec{}
+	want := wrapperspb.String("proto round trip")
+
+	buf, err := sc.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	got := &wrapperspb.StringValue{}
+	if err := sc.Unmarshal(buf, got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if got.GetValue() != want.GetValue() {
+		t.Fatalf("got %q, want %q", got.GetValue(), want.GetValue())
+	}
+}
@@ -1,40 +1,45 @@
 package grpc
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sync"
 
-	"github.com/unistack-org/micro/v3/codec"
+	"go.unistack.org/micro/v3/codec"
 )
 
 var (
 	maxInt = int(^uint(0) >> 1)
+
+	bufPool = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
 )
 
 func (c *grpcCodec) decode(r io.Reader) (uint8, []byte, error) {
-	header := make([]byte, 5)
+	var header [5]byte
 
 	// read the header
-	if n, err := r.Read(header[:]); err != nil {
-		if err == io.EOF && n == 0 {
-			return 0, nil, nil
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, nil, io.EOF
 		}
 		return 0, nil, err
 	}
 
 	// get encoding format e.g compressed
-	cf := uint8(header[0])
+	cf := header[0]
 
 	// get message length
 	length := binary.BigEndian.Uint32(header[1:])
 
-	// no encoding format
+	// no payload
 	if length == 0 {
 		return cf, nil, nil
 	}
 
-	//
 	if int64(length) > int64(maxInt) {
 		return cf, nil, fmt.Errorf("grpc: received message larger than max length allowed on current machine (%d vs. %d)", length, maxInt)
 	}
@@ -42,28 +47,33 @@ func (c *grpcCodec) decode(r io.Reader) (uint8, []byte, error) {
 		return cf, nil, fmt.Errorf("grpc: received message larger than max (%d vs. %d)", length, codec.DefaultMaxMsgSize)
 	}
 
-	msg := make([]byte, int(length))
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
 
-	if _, err := r.Read(msg); err != nil {
+	if _, err := io.CopyN(buf, r, int64(length)); err != nil {
 		if err == io.EOF {
 			err = io.ErrUnexpectedEOF
 		}
 		return cf, nil, err
 	}
 
+	msg := make([]byte, length)
+	copy(msg, buf.Bytes())
+
 	return cf, msg, nil
 }
 
 func (c *grpcCodec) encode(cf uint8, buf []byte, w io.Writer) error {
-	header := make([]byte, 5)
+	var header [5]byte
 
 	// set compression
-	header[0] = byte(cf)
+	header[0] = cf
 
 	// write length as header
 	binary.BigEndian.PutUint32(header[1:], uint32(len(buf)))
 
-	// read the header
+	// write the header
 	if _, err := w.Write(header[:]); err != nil {
 		return err
 	}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	c := &grpcCodec{}
+	buf := new(bytes.Buffer)
+
+	payload := []byte("hello world")
+	if err := c.encode(1, payload, buf); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	cf, got, err := c.decode(buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if cf != 1 {
+		t.Fatalf("cf = %d, want 1", cf)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("got %q, want %q", got, payload)
+	}
+}
+
+func TestDecodeEmptyMessage(t *testing.T) {
+	c := &grpcCodec{}
+	buf := new(bytes.Buffer)
+
+	if err := c.encode(0, nil, buf); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	cf, got, err := c.decode(buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if cf != 0 || got != nil {
+		t.Fatalf("got (%d, %q), want (0, nil)", cf, got)
+	}
+}
+
+func TestDecodeEOF(t *testing.T) {
+	c := &grpcCodec{}
+	if _, _, err := c.decode(bytes.NewReader(nil)); err != io.EOF {
+		t.Fatalf("err = %v, want io.EOF", err)
+	}
+}